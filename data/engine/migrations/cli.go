@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"context"
+	"flag"
+	"fmt"
+)
+
+// Run implements a small `migrate up|down|status` CLI on top of "m",
+// meant to be wired from `main`, e.g.:
+//
+//	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+//		if err := migrations.Run(ctx, m, os.Args[2:]); err != nil {
+//			log.Fatal(err)
+//		}
+//		return
+//	}
+func Run(ctx context.Context, m *Migrator, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("migrations: usage: migrate up|down|status")
+	}
+
+	switch args[0] {
+	case "up":
+		return m.Migrate(ctx)
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := fs.Int("steps", 1, "number of migrations to roll back")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		return m.Rollback(ctx, *steps)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied"
+			}
+			fmt.Printf("%-40s %s\n", s.ID, state)
+		}
+		return nil
+	default:
+		return fmt.Errorf("migrations: unknown command %q, want up|down|status", args[0])
+	}
+}
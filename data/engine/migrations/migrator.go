@@ -0,0 +1,369 @@
+// Package migrations implements a small, ordered schema-migrations runner
+// on top of `sql.Database`, analogous to xormigrate: migrations are plain
+// Go functions registered by ID, applied in ID order, and tracked in a
+// `schema_migrations` table so a restart never re-runs one.
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/BugSquad/Morshed_BackEnd/data/engine/sql"
+)
+
+// Migration is a single, ordered schema change. "ID" determines apply
+// order (lexicographic), so IDs are usually timestamp- or sequence-prefixed,
+// e.g. "20260729_1_create_users".
+type Migration struct {
+	ID          string
+	Description string
+	Up          func(ctx context.Context, db sql.Database) error
+	Down        func(ctx context.Context, db sql.Database) error
+}
+
+// checksum is a stable fingerprint of the migration's ID and description,
+// recorded in `schema_migrations` when the migration is applied. `Migrate`
+// does not read it back, so an applied migration whose description changed
+// later is not detected.
+func (m Migration) checksum() string {
+	sum := sha256.Sum256([]byte(m.ID + "\x00" + m.Description))
+	return hex.EncodeToString(sum[:])
+}
+
+// migrationsTable tracks which migrations have already been applied.
+const migrationsTable = "schema_migrations"
+
+// lockTable backs the advisory lock that keeps two `Migrate` calls from
+// running concurrently against the same database.
+const lockTable = "schema_migrations_lock"
+
+// ErrLocked is returned by `Migrator.Migrate` and `Migrator.Rollback` when
+// another runner already holds the migration lock.
+var ErrLocked = errors.New("migrations: another migrator is already running")
+
+// Migrator discovers and applies `Migration`s in ID order.
+type Migrator struct {
+	db      sql.Database
+	dialect sql.Dialect
+
+	mu         sync.Mutex
+	migrations map[string]Migration
+}
+
+// NewMigrator returns a new `Migrator` bound to "db". An optional `Dialect`
+// can be given to target a database engine other than MySQL (the default),
+// mirroring `sql.NewRepository`, so the migrator's own bookkeeping DDL and
+// placeholders stay portable too.
+func NewMigrator(db sql.Database, dialect ...sql.Dialect) *Migrator {
+	d := sql.Dialect(sql.MySQLDialect{})
+	if len(dialect) > 0 && dialect[0] != nil {
+		d = dialect[0]
+	}
+
+	return &Migrator{db: db, dialect: d, migrations: make(map[string]Migration)}
+}
+
+// Register adds "mig" to the migrator's set of known migrations.
+// Registering the same ID twice panics, duplicate IDs are always a
+// programmer error and would otherwise shadow each other silently.
+func (m *Migrator) Register(mig Migration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.migrations[mig.ID]; exists {
+		panic(fmt.Sprintf("migrations: duplicate migration id %q", mig.ID))
+	}
+
+	m.migrations[mig.ID] = mig
+}
+
+func (m *Migrator) sorted() []Migration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := make([]Migration, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		list = append(list, mig)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+func (m *Migrator) ensureTables(ctx context.Context) error {
+	_, err := m.db.Exec(ctx, fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS %s (
+			id VARCHAR(255) PRIMARY KEY,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			checksum VARCHAR(64) NOT NULL
+		)`, m.dialect.Quote(migrationsTable)))
+	if err != nil {
+		return err
+	}
+
+	// SMALLINT (not MySQL's TINYINT, which Postgres doesn't have) so the
+	// lock table DDL stays portable across all three dialects.
+	_, err = m.db.Exec(ctx, fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS %s (id SMALLINT PRIMARY KEY)`, m.dialect.Quote(lockTable)))
+	return err
+}
+
+// lock acquires the advisory lock, failing with `ErrLocked` if another
+// runner already holds it.
+func (m *Migrator) lock(ctx context.Context) error {
+	_, err := m.db.Exec(ctx, fmt.Sprintf("INSERT INTO %s (id) VALUES (1)", m.dialect.Quote(lockTable)))
+	if err != nil {
+		return ErrLocked
+	}
+	return nil
+}
+
+func (m *Migrator) unlock(ctx context.Context) error {
+	_, err := m.db.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE id = 1", m.dialect.Quote(lockTable)))
+	return err
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[string]bool, error) {
+	var ids []string
+	if err := m.db.Select(ctx, &ids, fmt.Sprintf("SELECT id FROM %s", m.dialect.Quote(migrationsTable))); err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set, nil
+}
+
+// Migrate applies every pending migration, in ID order, guarded by the
+// migrator's advisory lock so two runners can't apply migrations
+// concurrently. Each migration's `Up` step and its `schema_migrations`
+// bookkeeping insert run inside a single transaction (see `runUp`) when the
+// migrator's `Database` supports one.
+func (m *Migrator) Migrate(ctx context.Context) error {
+	if err := m.ensureTables(ctx); err != nil {
+		return err
+	}
+
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.unlock(ctx)
+
+	done, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, mig := range m.sorted() {
+		if done[mig.ID] {
+			continue
+		}
+
+		if mig.Up == nil {
+			return fmt.Errorf("migrations: %q has no Up step", mig.ID)
+		}
+
+		if err := m.runUp(ctx, mig); err != nil {
+			return fmt.Errorf("migrations: %q: %w", mig.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// runUp runs "mig"'s Up step and records it as applied. If the migrator's
+// `Database` implements `sql.Transactional` both happen inside one
+// transaction, so a failing bookkeeping insert rolls the schema change back
+// too instead of leaving it silently unrecorded (and re-applied next run).
+// Falls back to running both steps directly against "m.db" otherwise.
+func (m *Migrator) runUp(ctx context.Context, mig Migration) error {
+	txDB, ok := m.db.(sql.Transactional)
+	if !ok {
+		if err := mig.Up(ctx, m.db); err != nil {
+			return err
+		}
+		return m.recordApplied(ctx, m.db, mig)
+	}
+
+	tx, err := txDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := mig.Up(ctx, tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	if err := m.recordApplied(ctx, tx, mig); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *Migrator) recordApplied(ctx context.Context, db sql.Database, mig Migration) error {
+	q := sql.RewritePlaceholders(
+		fmt.Sprintf("INSERT INTO %s (id, checksum) VALUES (?, ?)", m.dialect.Quote(migrationsTable)), m.dialect)
+	_, err := db.Exec(ctx, q, mig.ID, mig.checksum())
+	return err
+}
+
+// runDown runs "mig"'s Down step and removes it from `schema_migrations`,
+// inside one transaction when supported, the same way `runUp` does.
+func (m *Migrator) runDown(ctx context.Context, mig Migration) error {
+	txDB, ok := m.db.(sql.Transactional)
+	if !ok {
+		if err := mig.Down(ctx, m.db); err != nil {
+			return err
+		}
+		return m.recordRolledBack(ctx, m.db, mig)
+	}
+
+	tx, err := txDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := mig.Down(ctx, tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	if err := m.recordRolledBack(ctx, tx, mig); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *Migrator) recordRolledBack(ctx context.Context, db sql.Database, mig Migration) error {
+	q := sql.RewritePlaceholders(fmt.Sprintf("DELETE FROM %s WHERE id = ?", m.dialect.Quote(migrationsTable)), m.dialect)
+	_, err := db.Exec(ctx, q, mig.ID)
+	return err
+}
+
+// Rollback reverts the last "steps" applied migrations, most recent first.
+func (m *Migrator) Rollback(ctx context.Context, steps int) error {
+	if steps <= 0 {
+		return nil
+	}
+
+	if err := m.ensureTables(ctx); err != nil {
+		return err
+	}
+
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.unlock(ctx)
+
+	done, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	all := m.sorted()
+	for i := len(all) - 1; i >= 0 && steps > 0; i-- {
+		mig := all[i]
+		if !done[mig.ID] {
+			continue
+		}
+
+		if mig.Down == nil {
+			return fmt.Errorf("migrations: %q has no Down step", mig.ID)
+		}
+
+		if err := m.runDown(ctx, mig); err != nil {
+			return fmt.Errorf("migrations: %q: %w", mig.ID, err)
+		}
+
+		steps--
+	}
+
+	return nil
+}
+
+// Status reports, for every registered migration in ID order, whether it
+// has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.ensureTables(ctx); err != nil {
+		return nil, err
+	}
+
+	done, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, mig := range m.sorted() {
+		statuses = append(statuses, MigrationStatus{
+			ID:      mig.ID,
+			Applied: done[mig.ID],
+		})
+	}
+
+	return statuses, nil
+}
+
+// MigrationStatus is one row of `Migrator.Status`'s report.
+type MigrationStatus struct {
+	ID      string
+	Applied bool
+}
+
+// CreateTableFromRecord synthesizes a baseline `CREATE TABLE` migration for
+// "rec" by inspecting its struct tags (see `sql.RegisterRecord`'s `db:"..."`
+// convention). It's meant as a starting point for a record's first
+// migration, not a full schema-diffing tool. An optional `sql.Dialect`
+// picks the column types and identifier quoting (MySQL by default),
+// mirroring `sql.NewRepository`/`NewMigrator`.
+func CreateTableFromRecord(rec sql.Record, dialect ...sql.Dialect) Migration {
+	d := sql.Dialect(sql.MySQLDialect{})
+	if len(dialect) > 0 && dialect[0] != nil {
+		d = dialect[0]
+	}
+
+	tableName := rec.TableName()
+	pk := rec.PrimaryKey()
+
+	var columns []string
+	for _, col := range sql.Columns(rec) {
+		columns = append(columns, fmt.Sprintf("%s %s", d.Quote(col.Name), d.ColumnType(col.Kind, col.PK)))
+	}
+
+	ddl := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n\t%s,\n\tPRIMARY KEY (%s)\n)",
+		d.Quote(tableName), strings.Join(columns, ",\n\t"), d.Quote(pk))
+
+	return Migration{
+		ID:          fmt.Sprintf("create_table_%s", tableName),
+		Description: fmt.Sprintf("create the %s table from its Record definition", tableName),
+		Up: func(ctx context.Context, db sql.Database) error {
+			_, err := db.Exec(ctx, ddl)
+			return err
+		},
+		Down: func(ctx context.Context, db sql.Database) error {
+			_, err := db.Exec(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %s", d.Quote(tableName)))
+			return err
+		},
+	}
+}
@@ -0,0 +1,126 @@
+package sql
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+)
+
+// Cursor directions accepted by `ListOptions.CursorDirection`. Empty
+// defaults to `CursorNext`.
+const (
+	CursorNext = "next"
+	CursorPrev = "prev"
+)
+
+// ErrInvalidCursor is returned by `DecodeCursor` (and therefore
+// `ListOptions.BuildQuery`/`Repository.List`) when a cursor token is
+// malformed or was encoded for a different `OrderByColumn`, e.g. after a
+// caller changed sort column between page requests.
+var ErrInvalidCursor = errors.New("sql: invalid cursor")
+
+// cursorPayload is the JSON shape encoded into a cursor token. "Column"
+// pins the token to the order-by column it was created for, so
+// `DecodeCursor` can reject it if the caller's `OrderByColumn` changed.
+type cursorPayload struct {
+	Column         string      `json:"column"`
+	LastOrderValue interface{} `json:"last_order_value"`
+	LastPK         interface{} `json:"last_pk"`
+}
+
+// EncodeCursor returns an opaque, base64-encoded keyset cursor pointing
+// just past the row identified by "orderVal" (the row's "orderCol" value)
+// and "pkVal" (its primary key, the tiebreaker), for use as
+// `ListOptions.Cursor`.
+func EncodeCursor(orderCol string, orderVal, pkVal interface{}) string {
+	b, _ := json.Marshal(cursorPayload{Column: orderCol, LastOrderValue: orderVal, LastPK: pkVal})
+	return base64.URLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor decodes a cursor token produced by `EncodeCursor`, returning
+// `ErrInvalidCursor` if "raw" isn't well-formed or wasn't encoded for
+// "orderCol".
+func DecodeCursor(raw, orderCol string) (orderVal, pkVal interface{}, err error) {
+	b, err := base64.URLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, nil, ErrInvalidCursor
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(b, &payload); err != nil {
+		return nil, nil, ErrInvalidCursor
+	}
+
+	if payload.Column == "" || payload.Column != orderCol {
+		return nil, nil, ErrInvalidCursor
+	}
+
+	return payload.LastOrderValue, payload.LastPK, nil
+}
+
+// buildPageInfo trims "dest" (a pointer to a slice of records, as passed to
+// `Repository.List`) down to "limit" rows, then reverses it back into sort
+// order if it was fetched for `CursorPrev`, and derives the
+// `PageInfo` cursors from the first/last remaining rows. "dest" holds
+// `limit+1` rows when another page exists in the requested direction.
+func buildPageInfo(rec Record, dest interface{}, limit uint64, opts ListOptions) PageInfo {
+	slice := reflect.ValueOf(dest).Elem()
+
+	n := slice.Len()
+	hasMore := uint64(n) > limit
+	if hasMore {
+		slice.Set(slice.Slice(0, int(limit)))
+		n = int(limit)
+	}
+
+	if opts.CursorDirection == CursorPrev {
+		reverseSlice(slice)
+	}
+
+	if n == 0 {
+		return PageInfo{HasMore: hasMore}
+	}
+
+	meta := getRecordMeta(rec)
+	first := indirectValue(slice.Index(0))
+	last := indirectValue(slice.Index(n - 1))
+
+	info := PageInfo{HasMore: hasMore}
+	if v, pk, ok := columnValues(first, meta, opts.OrderByColumn, opts.PrimaryKeyColumn); ok {
+		info.PrevCursor = EncodeCursor(opts.OrderByColumn, v, pk)
+	}
+	if v, pk, ok := columnValues(last, meta, opts.OrderByColumn, opts.PrimaryKeyColumn); ok {
+		info.NextCursor = EncodeCursor(opts.OrderByColumn, v, pk)
+	}
+
+	return info
+}
+
+func columnValues(v reflect.Value, meta *recordMeta, orderCol, pkCol string) (orderVal, pkVal interface{}, ok bool) {
+	order, ok1 := meta.byName[orderCol]
+	pk, ok2 := meta.byName[pkCol]
+	if !ok1 || !ok2 {
+		return nil, nil, false
+	}
+
+	return v.FieldByIndex(order.Index).Interface(), v.FieldByIndex(pk.Index).Interface(), true
+}
+
+func indirectValue(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	return v
+}
+
+// reverseSlice reverses "v" (a reflect.Slice) in place, used to restore
+// ascending request order after a `CursorPrev` page was fetched in reverse.
+func reverseSlice(v reflect.Value) {
+	tmp := reflect.New(v.Type().Elem()).Elem()
+	for i, j := 0, v.Len()-1; i < j; i, j = i+1, j-1 {
+		tmp.Set(v.Index(i))
+		v.Index(i).Set(v.Index(j))
+		v.Index(j).Set(tmp)
+	}
+}
@@ -0,0 +1,69 @@
+package sql
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestListOptionsBuildQueryCursorPrevFlipsOrder guards against the keyset
+// predicate and the ORDER BY direction disagreeing for CursorPrev: if the
+// query fetches "< cursor" but still sorts ASC, LIMIT picks the first page
+// of the whole table instead of the page immediately before the cursor.
+func TestListOptionsBuildQueryCursorPrevFlipsOrder(t *testing.T) {
+	token := EncodeCursor("created_at", "2020-01-01", int64(5))
+
+	opt := ListOptions{
+		Table: "widgets", OrderByColumn: "created_at", PrimaryKeyColumn: "id",
+		Limit: 10, Cursor: token, CursorDirection: CursorPrev, Dialect: MySQLDialect{},
+	}
+
+	q, args, err := opt.BuildQuery()
+	if err != nil {
+		t.Fatalf("BuildQuery() error = %v", err)
+	}
+	if len(args) != 2 {
+		t.Fatalf("BuildQuery() args = %v, want 2 (order value, pk)", args)
+	}
+
+	if !strings.Contains(q, "< (?, ?)") {
+		t.Errorf("BuildQuery() = %q, want a \"<\" keyset predicate for CursorPrev", q)
+	}
+	if !strings.Contains(q, "ORDER BY `created_at` DESC, `id` DESC") {
+		t.Errorf("BuildQuery() = %q, want the ORDER BY flipped to DESC to match the \"<\" predicate", q)
+	}
+}
+
+func TestListOptionsBuildQueryCursorNextKeepsOrder(t *testing.T) {
+	token := EncodeCursor("created_at", "2020-01-01", int64(5))
+
+	opt := ListOptions{
+		Table: "widgets", OrderByColumn: "created_at", PrimaryKeyColumn: "id",
+		Limit: 10, Cursor: token, CursorDirection: CursorNext, Dialect: MySQLDialect{},
+	}
+
+	q, _, err := opt.BuildQuery()
+	if err != nil {
+		t.Fatalf("BuildQuery() error = %v", err)
+	}
+
+	if !strings.Contains(q, "> (?, ?)") {
+		t.Errorf("BuildQuery() = %q, want a \">\" keyset predicate for CursorNext", q)
+	}
+	if !strings.Contains(q, "ORDER BY `created_at` ASC, `id` ASC") {
+		t.Errorf("BuildQuery() = %q, want ORDER BY to stay ASC for CursorNext", q)
+	}
+}
+
+func TestDecodeCursorRejectsMismatchedColumn(t *testing.T) {
+	token := EncodeCursor("created_at", "2020-01-01", int64(5))
+
+	if _, _, err := DecodeCursor(token, "updated_at"); err != ErrInvalidCursor {
+		t.Errorf("DecodeCursor() error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestDecodeCursorRejectsGarbage(t *testing.T) {
+	if _, _, err := DecodeCursor("not-a-valid-cursor!!", "created_at"); err != ErrInvalidCursor {
+		t.Errorf("DecodeCursor() error = %v, want ErrInvalidCursor", err)
+	}
+}
@@ -0,0 +1,216 @@
+package sql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Dialect abstracts the small set of SQL differences between database
+// engines that `Repository` needs to know about: placeholder syntax,
+// identifier quoting, and the LIMIT/OFFSET and RETURNING clauses.
+// Everything else (the actual SQL generated by `Repository` and
+// `ListOptions.BuildQuery`) is engine-agnostic and reused as-is.
+type Dialect interface {
+	// Placeholder returns the bind-parameter marker for the n-th (1-based)
+	// argument of a query, e.g. "?" for MySQL/SQLite or "$1" for Postgres.
+	Placeholder(n int) string
+	// Quote returns "ident" quoted the way the engine expects identifiers,
+	// e.g. `` `ident` `` for MySQL or `"ident"` for Postgres/SQLite.
+	Quote(ident string) string
+	// LimitOffset returns the full "LIMIT ... OFFSET ..." clause (including
+	// the leading space), or "" if both limit and offset are zero.
+	LimitOffset(limit, offset uint64) string
+	// ReturningID returns the clause appended to an INSERT statement to get
+	// the primary key back, e.g. "" for MySQL (relies on LAST_INSERT_ID)
+	// or "RETURNING pk" for Postgres.
+	ReturningID(table, pk string) string
+	// ColumnType returns the DDL column type for a Go field of reflect.Kind
+	// "kind", used by `migrations.CreateTableFromRecord` to synthesize a
+	// baseline schema from a `Record`'s struct tags. "isPK" requests the
+	// auto-incrementing primary-key variant for integer kinds.
+	ColumnType(kind reflect.Kind, isPK bool) string
+	// CurrentTimestamp returns the SQL expression for "now", used by
+	// `Repository.DeleteByID`'s soft-delete path to stamp the deleted-at
+	// column, e.g. "NOW()" for MySQL/Postgres or "CURRENT_TIMESTAMP" for
+	// SQLite (which has no NOW() function).
+	CurrentTimestamp() string
+}
+
+// defaultDialect is used by `NewRepository` and `ListOptions` when no
+// explicit `Dialect` is given, preserving the historical MySQL behavior.
+var defaultDialect Dialect = MySQLDialect{}
+
+func limitOffsetClause(limit, offset uint64) string {
+	var q string
+	if limit > 0 {
+		q += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	if offset > 0 {
+		q += fmt.Sprintf(" OFFSET %d", offset)
+	}
+	return q
+}
+
+// MySQLDialect is the `Dialect` implementation for MySQL/MariaDB.
+type MySQLDialect struct{}
+
+// Placeholder always returns "?", MySQL placeholders are not numbered.
+func (MySQLDialect) Placeholder(int) string { return "?" }
+
+// Quote wraps "ident" in backticks.
+func (MySQLDialect) Quote(ident string) string { return "`" + ident + "`" }
+
+// LimitOffset returns the standard "LIMIT n OFFSET m" clause.
+func (MySQLDialect) LimitOffset(limit, offset uint64) string {
+	return limitOffsetClause(limit, offset)
+}
+
+// ReturningID is unsupported on MySQL, callers should rely on
+// `sql.Result.LastInsertId` instead.
+func (MySQLDialect) ReturningID(table, pk string) string { return "" }
+
+// CurrentTimestamp returns MySQL's "NOW()".
+func (MySQLDialect) CurrentTimestamp() string { return "NOW()" }
+
+// ColumnType maps integer/float/bool kinds to MySQL's BIGINT/DOUBLE/BOOLEAN,
+// auto-incrementing integer primary keys via "BIGINT AUTO_INCREMENT", and
+// everything else to VARCHAR(255).
+func (MySQLDialect) ColumnType(kind reflect.Kind, isPK bool) string {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if isPK {
+			return "BIGINT AUTO_INCREMENT"
+		}
+		return "BIGINT"
+	case reflect.Float32, reflect.Float64:
+		return "DOUBLE"
+	case reflect.Bool:
+		return "BOOLEAN"
+	default:
+		return "VARCHAR(255)"
+	}
+}
+
+// PostgresDialect is the `Dialect` implementation for PostgreSQL.
+type PostgresDialect struct{}
+
+// Placeholder returns the numbered "$n" marker Postgres requires.
+func (PostgresDialect) Placeholder(n int) string { return fmt.Sprintf("$%d", n) }
+
+// Quote wraps "ident" in double quotes.
+func (PostgresDialect) Quote(ident string) string { return `"` + ident + `"` }
+
+// LimitOffset returns the standard "LIMIT n OFFSET m" clause, Postgres
+// accepts the same syntax as MySQL here.
+func (PostgresDialect) LimitOffset(limit, offset uint64) string {
+	return limitOffsetClause(limit, offset)
+}
+
+// ReturningID returns a "RETURNING pk" clause to append to an INSERT
+// statement so the generated primary key comes back with the result row.
+func (PostgresDialect) ReturningID(table, pk string) string {
+	return fmt.Sprintf("RETURNING %s", pk)
+}
+
+// CurrentTimestamp returns Postgres's "NOW()".
+func (PostgresDialect) CurrentTimestamp() string { return "NOW()" }
+
+// ColumnType maps integer kinds to BIGSERIAL (auto-increment primary keys)
+// or BIGINT, floats to DOUBLE PRECISION, bools to BOOLEAN (Postgres has no
+// bare MySQL-style TINYINT/DOUBLE), and everything else to VARCHAR(255).
+func (PostgresDialect) ColumnType(kind reflect.Kind, isPK bool) string {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if isPK {
+			return "BIGSERIAL"
+		}
+		return "BIGINT"
+	case reflect.Float32, reflect.Float64:
+		return "DOUBLE PRECISION"
+	case reflect.Bool:
+		return "BOOLEAN"
+	default:
+		return "VARCHAR(255)"
+	}
+}
+
+// SQLiteDialect is the `Dialect` implementation for SQLite.
+type SQLiteDialect struct{}
+
+// Placeholder always returns "?", SQLite placeholders are not numbered.
+func (SQLiteDialect) Placeholder(int) string { return "?" }
+
+// Quote wraps "ident" in double quotes.
+func (SQLiteDialect) Quote(ident string) string { return `"` + ident + `"` }
+
+// LimitOffset returns the standard "LIMIT n OFFSET m" clause.
+func (SQLiteDialect) LimitOffset(limit, offset uint64) string {
+	return limitOffsetClause(limit, offset)
+}
+
+// ReturningID is left unsupported here for simplicity, callers should rely
+// on `sql.Result.LastInsertId` instead (SQLite only gained RETURNING in
+// 3.35+, and driver support for it is inconsistent).
+func (SQLiteDialect) ReturningID(table, pk string) string { return "" }
+
+// CurrentTimestamp returns SQLite's "CURRENT_TIMESTAMP", SQLite has no
+// NOW() function.
+func (SQLiteDialect) CurrentTimestamp() string { return "CURRENT_TIMESTAMP" }
+
+// ColumnType maps integer kinds to INTEGER (SQLite's rowid-aliasing
+// "INTEGER PRIMARY KEY" handles auto-increment without a separate
+// modifier), floats to REAL, bools to BOOLEAN, and everything else to TEXT,
+// following SQLite's type-affinity conventions rather than MySQL's.
+func (SQLiteDialect) ColumnType(kind reflect.Kind, isPK bool) string {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "INTEGER"
+	case reflect.Float32, reflect.Float64:
+		return "REAL"
+	case reflect.Bool:
+		return "BOOLEAN"
+	default:
+		return "TEXT"
+	}
+}
+
+// RewritePlaceholders is the exported form of `rewritePlaceholders`, for
+// packages outside `sql` that build raw SQL against a `Dialect` directly
+// (e.g. `migrations`) instead of going through `Query`/`ListOptions`.
+func RewritePlaceholders(query string, dialect Dialect) string {
+	return rewritePlaceholders(query, dialect)
+}
+
+// rewritePlaceholders rewrites every "?" in "query" (the form every builder
+// in this package emits) into the bind-marker "dialect" expects, numbered in
+// order of appearance. It's a no-op for dialects whose `Placeholder` always
+// returns "?".
+func rewritePlaceholders(query string, dialect Dialect) string {
+	if dialect == nil {
+		dialect = defaultDialect
+	}
+
+	if strings.Count(query, "?") == 0 {
+		return query
+	}
+
+	var (
+		b strings.Builder
+		n int
+	)
+
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString(dialect.Placeholder(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
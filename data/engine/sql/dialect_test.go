@@ -0,0 +1,104 @@
+package sql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDialectMatrix(t *testing.T) {
+	cases := []struct {
+		dialect     Dialect
+		placeholder string // Placeholder(2)
+		quoted      string // Quote("id")
+		limitOffset string // LimitOffset(10, 20)
+	}{
+		{MySQLDialect{}, "?", "`id`", " LIMIT 10 OFFSET 20"},
+		{PostgresDialect{}, "$2", `"id"`, " LIMIT 10 OFFSET 20"},
+		{SQLiteDialect{}, "?", `"id"`, " LIMIT 10 OFFSET 20"},
+	}
+
+	for _, c := range cases {
+		t.Run(reflect.TypeOf(c.dialect).Name(), func(t *testing.T) {
+			if got := c.dialect.Placeholder(2); got != c.placeholder {
+				t.Errorf("Placeholder(2) = %q, want %q", got, c.placeholder)
+			}
+			if got := c.dialect.Quote("id"); got != c.quoted {
+				t.Errorf("Quote(%q) = %q, want %q", "id", got, c.quoted)
+			}
+			if got := c.dialect.LimitOffset(10, 20); got != c.limitOffset {
+				t.Errorf("LimitOffset(10, 20) = %q, want %q", got, c.limitOffset)
+			}
+		})
+	}
+}
+
+func TestRewritePlaceholders(t *testing.T) {
+	const query = "a = ? AND b = ?"
+
+	cases := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{MySQLDialect{}, "a = ? AND b = ?"},
+		{PostgresDialect{}, "a = $1 AND b = $2"},
+		{SQLiteDialect{}, "a = ? AND b = ?"},
+	}
+
+	for _, c := range cases {
+		t.Run(reflect.TypeOf(c.dialect).Name(), func(t *testing.T) {
+			if got := rewritePlaceholders(query, c.dialect); got != c.want {
+				t.Errorf("rewritePlaceholders(%q) = %q, want %q", query, got, c.want)
+			}
+		})
+	}
+}
+
+// TestListOptionsBuildQueryOffset runs the offset pagination path against
+// every dialect, asserting the table/column quoting it emits and that the
+// query carries no bind arguments.
+func TestListOptionsBuildQueryOffset(t *testing.T) {
+	cases := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{MySQLDialect{}, "SELECT * FROM `widgets` ORDER BY `created_at` ASC LIMIT 10 OFFSET 20"},
+		{PostgresDialect{}, `SELECT * FROM "widgets" ORDER BY "created_at" ASC LIMIT 10 OFFSET 20`},
+		{SQLiteDialect{}, `SELECT * FROM "widgets" ORDER BY "created_at" ASC LIMIT 10 OFFSET 20`},
+	}
+
+	for _, c := range cases {
+		t.Run(reflect.TypeOf(c.dialect).Name(), func(t *testing.T) {
+			opt := ListOptions{Table: "widgets", OrderByColumn: "created_at", Limit: 10, Offset: 20, Dialect: c.dialect}
+
+			q, args, err := opt.BuildQuery()
+			if err != nil {
+				t.Fatalf("BuildQuery() error = %v", err)
+			}
+			if len(args) != 0 {
+				t.Fatalf("BuildQuery() args = %v, want none", args)
+			}
+			if q != c.want {
+				t.Errorf("BuildQuery() = %q, want %q", q, c.want)
+			}
+		})
+	}
+}
+
+func TestDialectCurrentTimestamp(t *testing.T) {
+	cases := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{MySQLDialect{}, "NOW()"},
+		{PostgresDialect{}, "NOW()"},
+		{SQLiteDialect{}, "CURRENT_TIMESTAMP"},
+	}
+
+	for _, c := range cases {
+		t.Run(reflect.TypeOf(c.dialect).Name(), func(t *testing.T) {
+			if got := c.dialect.CurrentTimestamp(); got != c.want {
+				t.Errorf("CurrentTimestamp() = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
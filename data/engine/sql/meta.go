@@ -0,0 +1,266 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// columnMeta describes one `db`-tagged struct field mapped to a column.
+type columnMeta struct {
+	Name     string // db column name.
+	Kind     reflect.Kind
+	Index    []int // field index path, supports embedded structs.
+	PK       bool
+	Auto     bool // auto-generated (e.g. AUTO_INCREMENT), skipped on Insert.
+	ReadOnly bool // never written by Insert/Update/PartialUpdate.
+}
+
+// recordMeta is the cached, reflection-derived metadata of a `Record` type,
+// built once by `RegisterRecord` from its `db:"col,modifier,..."` struct tags.
+type recordMeta struct {
+	columns []columnMeta
+	byName  map[string]columnMeta
+	pk      string
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[reflect.Type]*recordMeta)
+)
+
+// RegisterRecord walks "of"'s struct fields once, reading `db:"col_name"`
+// tags (with optional ",pk", ",auto" and ",readonly" modifiers), and caches
+// the resulting metadata so `PartialUpdate`, `Insert` and `Update` don't
+// need a hand-maintained parallel schema. Safe to call multiple times, and
+// concurrently, for the same type, later calls are no-ops. Embedded structs
+// and pointer fields are supported.
+func RegisterRecord(of Record) {
+	typ := indirectType(reflect.TypeOf(of))
+
+	registryMu.RLock()
+	_, ok := registry[typ]
+	registryMu.RUnlock()
+	if ok {
+		return
+	}
+
+	meta := buildRecordMeta(typ, of.PrimaryKey())
+
+	registryMu.Lock()
+	registry[typ] = meta
+	registryMu.Unlock()
+}
+
+// getRecordMeta returns the cached metadata of "rec", registering it first
+// if this is the first time it's seen.
+func getRecordMeta(rec Record) *recordMeta {
+	typ := indirectType(reflect.TypeOf(rec))
+
+	registryMu.RLock()
+	meta, ok := registry[typ]
+	registryMu.RUnlock()
+	if ok {
+		return meta
+	}
+
+	RegisterRecord(rec)
+
+	registryMu.RLock()
+	meta = registry[typ]
+	registryMu.RUnlock()
+	return meta
+}
+
+// ColumnMeta is the exported view of a record's column metadata, for
+// packages outside `sql` that need a record's column list without
+// re-deriving it with their own reflection pass (e.g.
+// `migrations.CreateTableFromRecord`).
+type ColumnMeta struct {
+	Name string
+	Kind reflect.Kind
+	PK   bool
+}
+
+// Columns returns "rec"'s registered column metadata (see `RegisterRecord`),
+// in struct-tag declaration order, including columns flattened in from
+// embedded structs.
+func Columns(rec Record) []ColumnMeta {
+	meta := getRecordMeta(rec)
+
+	cols := make([]ColumnMeta, len(meta.columns))
+	for i, c := range meta.columns {
+		cols[i] = ColumnMeta{Name: c.Name, Kind: c.Kind, PK: c.PK}
+	}
+
+	return cols
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func buildRecordMeta(typ reflect.Type, pk string) *recordMeta {
+	meta := &recordMeta{byName: make(map[string]columnMeta), pk: pk}
+
+	walkFields(typ, nil, func(idx []int, field reflect.StructField) {
+		tag := field.Tag.Get("db")
+		if tag == "" || tag == "-" {
+			return
+		}
+
+		parts := strings.Split(tag, ",")
+		col := columnMeta{Name: strings.TrimSpace(parts[0]), Kind: indirectType(field.Type).Kind(), Index: idx}
+
+		for _, mod := range parts[1:] {
+			switch strings.TrimSpace(mod) {
+			case "pk":
+				col.PK = true
+			case "auto":
+				col.Auto = true
+			case "readonly":
+				col.ReadOnly = true
+			}
+		}
+
+		if col.Name == pk {
+			col.PK = true
+		}
+
+		meta.columns = append(meta.columns, col)
+		meta.byName[col.Name] = col
+	})
+
+	return meta
+}
+
+// walkFields visits every `db`-tagged field of "typ", recursing into
+// anonymous (embedded) struct fields so their columns are flattened into
+// the parent's metadata.
+func walkFields(typ reflect.Type, prefix []int, visit func(idx []int, field reflect.StructField)) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		idx := append(append([]int{}, prefix...), i)
+
+		if field.Anonymous {
+			if ft := indirectType(field.Type); ft.Kind() == reflect.Struct {
+				walkFields(ft, idx, visit)
+				continue
+			}
+		}
+
+		visit(idx, field)
+	}
+}
+
+// kindMatches reports whether "v"'s dynamic type is compatible with "kind",
+// mirroring the trivial string/int/bool validation `PartialUpdate` has
+// always performed. Any other Go kind is left unchecked.
+func kindMatches(kind reflect.Kind, v interface{}) bool {
+	switch v.(type) {
+	case string:
+		return kind == reflect.String
+	case int:
+		return kind == reflect.Int
+	case bool:
+		return kind == reflect.Bool
+	default:
+		return true
+	}
+}
+
+// Insert adds "entity" to the table, using the column metadata registered
+// for the repository's record (see `RegisterRecord`) to build the column
+// list and argument slice. `readonly` fields are always excluded, `auto`
+// fields (e.g. AUTO_INCREMENT primary keys) are skipped so the database
+// generates them.
+func (r *Repository) Insert(ctx context.Context, entity Record) (int64, error) {
+	meta := getRecordMeta(r.rec)
+	v := reflect.Indirect(reflect.ValueOf(entity))
+
+	var (
+		cols         []string
+		placeholders []string
+		values       []interface{}
+	)
+
+	for _, col := range meta.columns {
+		if col.Auto || col.ReadOnly {
+			continue
+		}
+
+		cols = append(cols, r.dialect.Quote(col.Name))
+		placeholders = append(placeholders, "?")
+		values = append(values, v.FieldByIndex(col.Index).Interface())
+	}
+
+	q := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		r.dialect.Quote(r.rec.TableName()), strings.Join(cols, ", "), strings.Join(placeholders, ", "))
+
+	if returning := r.dialect.ReturningID(r.rec.TableName(), meta.pk); returning != "" {
+		q = rewritePlaceholders(q+" "+returning, r.dialect)
+
+		var id int64
+		err := r.db.Select(ctx, &id, q, values...)
+		return id, err
+	}
+
+	q = rewritePlaceholders(q, r.dialect)
+
+	res, err := r.db.Exec(ctx, q, values...)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.LastInsertId()
+}
+
+// Update replaces every non-readonly, non-auto column of "entity" in the
+// table, matched by its primary key value, using the same metadata as
+// `Insert`.
+func (r *Repository) Update(ctx context.Context, entity Record) (int, error) {
+	meta := getRecordMeta(r.rec)
+	v := reflect.Indirect(reflect.ValueOf(entity))
+
+	var (
+		keyLines []string
+		values   []interface{}
+		pkValue  interface{}
+	)
+
+	for _, col := range meta.columns {
+		fv := v.FieldByIndex(col.Index).Interface()
+
+		if col.PK {
+			pkValue = fv
+			continue
+		}
+
+		if col.Auto || col.ReadOnly {
+			continue
+		}
+
+		keyLines = append(keyLines, fmt.Sprintf("%s = ?", r.dialect.Quote(col.Name)))
+		values = append(values, fv)
+	}
+
+	if pkValue == nil || len(keyLines) == 0 {
+		return 0, ErrUnprocessable
+	}
+
+	q := fmt.Sprintf("UPDATE %s SET %s WHERE %s = ?",
+		r.dialect.Quote(r.rec.TableName()), strings.Join(keyLines, ", "), r.dialect.Quote(meta.pk))
+	q = rewritePlaceholders(q, r.dialect)
+
+	res, err := r.db.Exec(ctx, q, append(values, pkValue)...)
+	if err != nil {
+		return 0, err
+	}
+
+	return GetAffectedRows(res), nil
+}
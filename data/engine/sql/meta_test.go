@@ -0,0 +1,69 @@
+package sql
+
+import (
+	"reflect"
+	"testing"
+)
+
+// metaTestBase is embedded into metaTestRecord to exercise the embedded-
+// struct flattening `walkFields` performs.
+type metaTestBase struct {
+	ID   int64  `db:"id,pk,auto"`
+	Name string `db:"name"`
+}
+
+type metaTestRecord struct {
+	metaTestBase
+	Note     *string `db:"note"`
+	Internal string  `db:"-"`
+	Untagged string
+}
+
+func (metaTestRecord) TableName() string  { return "meta_test_records" }
+func (metaTestRecord) PrimaryKey() string { return "id" }
+
+func TestGetRecordMetaEmbeddedAndPointerFields(t *testing.T) {
+	meta := getRecordMeta(metaTestRecord{})
+
+	if meta.pk != "id" {
+		t.Errorf("getRecordMeta().pk = %q, want %q", meta.pk, "id")
+	}
+
+	id, ok := meta.byName["id"]
+	if !ok || !id.PK || !id.Auto {
+		t.Errorf("getRecordMeta().byName[%q] = %+v, want PK=true Auto=true", "id", id)
+	}
+
+	name, ok := meta.byName["name"]
+	if !ok || name.PK || name.Auto {
+		t.Errorf("getRecordMeta().byName[%q] = %+v, want a plain column", "name", name)
+	}
+
+	note, ok := meta.byName["note"]
+	if !ok {
+		t.Fatalf("getRecordMeta() missing pointer field column %q", "note")
+	}
+	if note.Kind != reflect.String {
+		t.Errorf("getRecordMeta().byName[%q].Kind = %v, want %v (pointer fields are indirected)", "note", note.Kind, reflect.String)
+	}
+
+	if _, ok := meta.byName["Untagged"]; ok {
+		t.Errorf("getRecordMeta() included an untagged field")
+	}
+	if _, ok := meta.byName["Internal"]; ok {
+		t.Errorf(`getRecordMeta() included a db:"-" field`)
+	}
+
+	if len(meta.columns) != 3 {
+		t.Errorf("getRecordMeta().columns = %+v, want 3 entries (id, name, note)", meta.columns)
+	}
+}
+
+func TestGetRecordMetaCachesByType(t *testing.T) {
+	first := getRecordMeta(metaTestRecord{})
+	second := getRecordMeta(metaTestRecord{})
+
+	if first != second {
+		t.Errorf("getRecordMeta() returned distinct *recordMeta for repeated calls with the same type")
+	}
+}
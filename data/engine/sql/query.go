@@ -0,0 +1,348 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// allowedOperators is the whitelist of comparison operators accepted by
+// `Query.Where` and `Query.OrWhere`. Anything not listed here
+// is silently normalized to "=" so that a caller can never smuggle arbitrary
+// SQL through the operator argument.
+var allowedOperators = map[string]bool{
+	"=":        true,
+	"!=":       true,
+	"<>":       true,
+	"<":        true,
+	"<=":       true,
+	">":        true,
+	">=":       true,
+	"LIKE":     true,
+	"NOT LIKE": true,
+}
+
+// condition is a single predicate accumulated by a `Query`, either a plain
+// comparison or a nested group produced by `Query.Group`.
+type condition struct {
+	or    bool
+	raw   string
+	args  []interface{}
+	group *Query
+}
+
+// Query is a chainable, parameterized SELECT builder. It exists so that
+// callers that need compound predicates (OR, IN, BETWEEN) don't have to drop
+// down to raw SQL and lose the column whitelist that `Query` enforces on
+// every identifier it accepts.
+// The zero value is not usable, use `NewQuery`.
+type Query struct {
+	table   string
+	columns []string // whitelist of allowed identifiers, nil disables validation.
+	dialect Dialect  // identifier quoting, never nil, see `NewQuery`.
+
+	conditions []condition
+
+	groupBy    []string
+	having     string
+	havingArgs []interface{}
+
+	orderBy []string
+
+	limit     uint64
+	hasLimit  bool
+	offset    uint64
+	hasOffset bool
+}
+
+// NewQuery returns a new `Query` against "table". "columns" is the whitelist
+// of identifiers allowed in Where/OrderBy/GroupBy/Having, pass nil to skip
+// validation (e.g. for internal, non-user-facing callers). An optional
+// `Dialect` controls identifier quoting, defaulting to `defaultDialect`
+// (MySQL) when omitted, mirroring `NewRepository`.
+func NewQuery(table string, columns []string, dialect ...Dialect) *Query {
+	d := defaultDialect
+	if len(dialect) > 0 && dialect[0] != nil {
+		d = dialect[0]
+	}
+
+	return &Query{table: table, columns: columns, dialect: d}
+}
+
+// queryFor returns a `Query` pre-populated with "rec"'s table and the
+// column whitelist from its registered `recordMeta` (see `RegisterRecord`),
+// ready to be passed to `Repository.Find`/`CountQ`.
+func queryFor(rec Record, dialect Dialect) *Query {
+	meta := getRecordMeta(rec)
+
+	columns := make([]string, len(meta.columns))
+	for i, c := range meta.columns {
+		columns[i] = c.Name
+	}
+
+	return NewQuery(rec.TableName(), columns, dialect)
+}
+
+func (q *Query) isAllowed(col string) bool {
+	if q.columns == nil {
+		return true
+	}
+
+	for _, c := range q.columns {
+		if c == col {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normalizeOperator(op string) string {
+	op = strings.ToUpper(strings.TrimSpace(op))
+	if !allowedOperators[op] {
+		return "="
+	}
+
+	return op
+}
+
+// Where appends an "AND col op ?" predicate. Unknown columns (when a
+// whitelist is set) and unknown operators are dropped/normalized instead of
+// returned as an error, mirroring `ParseOrder`'s defensive defaults.
+func (q *Query) Where(col, op string, val interface{}) *Query {
+	return q.where(false, col, op, val)
+}
+
+// OrWhere appends an "OR col op ?" predicate.
+func (q *Query) OrWhere(col, op string, val interface{}) *Query {
+	return q.where(true, col, op, val)
+}
+
+func (q *Query) where(or bool, col, op string, val interface{}) *Query {
+	if !q.isAllowed(col) {
+		return q
+	}
+
+	q.conditions = append(q.conditions, condition{
+		or:   or,
+		raw:  fmt.Sprintf("%s %s ?", q.dialect.Quote(col), normalizeOperator(op)),
+		args: []interface{}{val},
+	})
+
+	return q
+}
+
+// WhereIn appends an "AND col IN (?, ?, ...)" predicate. An empty "vals" has
+// no value that could ever match, so it renders the always-false "1=0"
+// instead of silently dropping the condition, otherwise an
+// authorization-style `WhereIn("tenant_id", allowedIDs...)` with no allowed
+// IDs would match every tenant's rows instead of none.
+func (q *Query) WhereIn(col string, vals ...interface{}) *Query {
+	if !q.isAllowed(col) {
+		return q
+	}
+
+	if len(vals) == 0 {
+		q.conditions = append(q.conditions, condition{raw: "1=0"})
+		return q
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(vals)), ", ")
+	q.conditions = append(q.conditions, condition{
+		raw:  fmt.Sprintf("%s IN (%s)", q.dialect.Quote(col), placeholders),
+		args: vals,
+	})
+
+	return q
+}
+
+// WhereBetween appends an "AND col BETWEEN ? AND ?" predicate.
+func (q *Query) WhereBetween(col string, lo, hi interface{}) *Query {
+	if !q.isAllowed(col) {
+		return q
+	}
+
+	q.conditions = append(q.conditions, condition{
+		raw:  fmt.Sprintf("%s BETWEEN ? AND ?", q.dialect.Quote(col)),
+		args: []interface{}{lo, hi},
+	})
+
+	return q
+}
+
+// Group appends a parenthesized, OR-able group of predicates built by "fn",
+// e.g. `q.Group(func(g *Query) { g.Where("a", "=", 1).OrWhere("b", "=", 2) })`
+// emits "AND (a = ? OR b = ?)".
+func (q *Query) Group(fn func(*Query)) *Query {
+	g := NewQuery(q.table, q.columns, q.dialect)
+	fn(g)
+
+	q.conditions = append(q.conditions, condition{group: g})
+	return q
+}
+
+// OrderBy appends a "col dir" ordering clause, "dir" is normalized through
+// `ParseOrder`.
+func (q *Query) OrderBy(col, dir string) *Query {
+	if !q.isAllowed(col) {
+		return q
+	}
+
+	q.orderBy = append(q.orderBy, fmt.Sprintf("%s %s", q.dialect.Quote(col), ParseOrder(dir)))
+	return q
+}
+
+// GroupBy appends columns to the GROUP BY clause.
+func (q *Query) GroupBy(cols ...string) *Query {
+	for _, c := range cols {
+		if q.isAllowed(c) {
+			q.groupBy = append(q.groupBy, q.dialect.Quote(c))
+		}
+	}
+
+	return q
+}
+
+// Having sets the HAVING clause, "expr" may contain "?" placeholders
+// matching "args" in order. It is only emitted when `GroupBy` was also used.
+func (q *Query) Having(expr string, args ...interface{}) *Query {
+	q.having = expr
+	q.havingArgs = args
+	return q
+}
+
+// Limit sets the LIMIT clause.
+func (q *Query) Limit(n uint64) *Query {
+	q.limit = n
+	q.hasLimit = true
+	return q
+}
+
+// Offset sets the OFFSET clause.
+func (q *Query) Offset(n uint64) *Query {
+	q.offset = n
+	q.hasOffset = true
+	return q
+}
+
+// Build renders the accumulated clauses into a parameterized SQL string and
+// its positional arguments. Placeholders are always "?", whatever the
+// query's `Dialect`; `Repository.Find` rewrites them for the repository's
+// `Dialect` before running the query.
+func (q *Query) Build() (query string, args []interface{}) {
+	query = fmt.Sprintf("SELECT * FROM %s", q.dialect.Quote(q.table))
+
+	if where, whereArgs := q.buildWhere(); where != "" {
+		query += " WHERE " + where
+		args = append(args, whereArgs...)
+	}
+
+	if len(q.groupBy) > 0 {
+		query += " GROUP BY " + strings.Join(q.groupBy, ", ")
+
+		if q.having != "" {
+			query += " HAVING " + q.having
+			args = append(args, q.havingArgs...)
+		}
+	}
+
+	if len(q.orderBy) > 0 {
+		query += " ORDER BY " + strings.Join(q.orderBy, ", ")
+	}
+
+	if q.hasLimit {
+		query += fmt.Sprintf(" LIMIT %d", q.limit)
+	}
+
+	if q.hasOffset {
+		query += fmt.Sprintf(" OFFSET %d", q.offset)
+	}
+
+	return query, args
+}
+
+func (q *Query) buildWhere() (string, []interface{}) {
+	var (
+		b    strings.Builder
+		args []interface{}
+	)
+
+	for i, c := range q.conditions {
+		if i > 0 {
+			if c.or {
+				b.WriteString(" OR ")
+			} else {
+				b.WriteString(" AND ")
+			}
+		}
+
+		if c.group != nil {
+			groupWhere, groupArgs := c.group.buildWhere()
+			b.WriteString("(" + groupWhere + ")")
+			args = append(args, groupArgs...)
+			continue
+		}
+
+		b.WriteString(c.raw)
+		args = append(args, c.args...)
+	}
+
+	return b.String(), args
+}
+
+// Query returns a new `Query` against the repository's own table, with its
+// column whitelist and identifier quoting derived from the record's
+// registered metadata (see `RegisterRecord`) and the repository's
+// `Dialect`. Prefer this over `NewQuery` when filtering the bound record's
+// own table, it's what keeps `Find`/`CountQ` identifier-injection-safe.
+func (r *Repository) Query() *Query {
+	return queryFor(r.rec, r.dialect)
+}
+
+// Find binds the records matched by "q" to "dest".
+func (r *Repository) Find(ctx context.Context, dest interface{}, q *Query) error {
+	query, args := q.Build()
+	query = rewritePlaceholders(query, r.dialect)
+	return r.db.Select(ctx, dest, query, args...)
+}
+
+// CountQ returns the total records matched by "q", ignoring its
+// limit/offset/order-by clauses. If "q" has a `GroupBy`, a plain
+// "SELECT COUNT(*) ... GROUP BY" would return one row per group instead of
+// an overall total, so the grouped query is wrapped as a subquery and the
+// groups themselves are counted instead. Any `Having` is carried into that
+// subquery too, otherwise the groups counted here wouldn't match the rows
+// `Find` (which does apply `Having`) actually returns.
+func (r *Repository) CountQ(ctx context.Context, q *Query) (total int64, err error) {
+	where, args := q.buildWhere()
+
+	var query string
+	if len(q.groupBy) > 0 {
+		inner := fmt.Sprintf("SELECT 1 FROM %s", q.dialect.Quote(q.table))
+		if where != "" {
+			inner += " WHERE " + where
+		}
+		inner += " GROUP BY " + strings.Join(q.groupBy, ", ")
+
+		if q.having != "" {
+			inner += " HAVING " + q.having
+			args = append(args, q.havingArgs...)
+		}
+
+		query = fmt.Sprintf("SELECT COUNT(*) FROM (%s) AS grouped", inner)
+	} else {
+		query = fmt.Sprintf("SELECT COUNT(*) FROM %s", q.dialect.Quote(q.table))
+		if where != "" {
+			query += " WHERE " + where
+		}
+	}
+
+	query = rewritePlaceholders(query, r.dialect)
+
+	if err = r.db.Select(ctx, &total, query, args...); err == sql.ErrNoRows {
+		err = nil
+	}
+
+	return
+}
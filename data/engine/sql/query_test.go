@@ -0,0 +1,184 @@
+package sql
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// fakeResult is a minimal database/sql.Result for tests that don't have a
+// real driver.
+type fakeResult struct {
+	lastInsertID int64
+	rowsAffected int64
+}
+
+func (r fakeResult) LastInsertId() (int64, error) { return r.lastInsertID, nil }
+func (r fakeResult) RowsAffected() (int64, error) { return r.rowsAffected, nil }
+
+// fakeCall records one Exec/Select/Get invocation against a fakeDB, for
+// assertions on the generated SQL and its arguments.
+type fakeCall struct {
+	query string
+	args  []interface{}
+}
+
+// fakeDB is a minimal, in-memory Database used to test statement generation
+// (and, via fakeTx, transaction bookkeeping) without a real driver.
+type fakeDB struct {
+	execs   []fakeCall
+	selects []fakeCall
+	gets    []fakeCall
+
+	execErr   error
+	selectErr error
+	getErr    error
+
+	result fakeResult
+}
+
+func (d *fakeDB) Exec(ctx context.Context, query string, args ...interface{}) (Result, error) {
+	d.execs = append(d.execs, fakeCall{query, args})
+	if d.execErr != nil {
+		return nil, d.execErr
+	}
+	return d.result, nil
+}
+
+func (d *fakeDB) Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	d.selects = append(d.selects, fakeCall{query, args})
+	return d.selectErr
+}
+
+func (d *fakeDB) Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	d.gets = append(d.gets, fakeCall{query, args})
+	return d.getErr
+}
+
+func TestQueryWhereQuotesAndWhitelists(t *testing.T) {
+	q := queryFor(metaTestRecord{}, MySQLDialect{})
+	q.Where("name", "=", "ada")
+
+	query, args := q.Build()
+	if want := "SELECT * FROM `meta_test_records` WHERE `name` = ?"; query != want {
+		t.Errorf("Build() = %q, want %q", query, want)
+	}
+	if len(args) != 1 || args[0] != "ada" {
+		t.Errorf("Build() args = %v, want [ada]", args)
+	}
+}
+
+func TestQueryWhereDropsUnwhitelistedColumn(t *testing.T) {
+	q := queryFor(metaTestRecord{}, MySQLDialect{})
+	q.Where("not_a_real_column", "=", "x")
+
+	query, args := q.Build()
+	if want := "SELECT * FROM `meta_test_records`"; query != want {
+		t.Errorf("Build() = %q, want %q (unwhitelisted column must be dropped)", query, want)
+	}
+	if len(args) != 0 {
+		t.Errorf("Build() args = %v, want none", args)
+	}
+}
+
+func TestQueryWhereNormalizesUnknownOperator(t *testing.T) {
+	q := queryFor(metaTestRecord{}, MySQLDialect{})
+	q.Where("name", "; DROP TABLE meta_test_records; --", "x")
+
+	query, _ := q.Build()
+	if !strings.Contains(query, "`name` = ?") {
+		t.Errorf("Build() = %q, want the unknown operator normalized to \"=\"", query)
+	}
+}
+
+func TestQueryWhereInEmptyValsMatchesNothing(t *testing.T) {
+	q := queryFor(metaTestRecord{}, MySQLDialect{})
+	q.WhereIn("id")
+
+	query, args := q.Build()
+	if !strings.Contains(query, "1=0") {
+		t.Errorf("Build() = %q, want the always-false \"1=0\" predicate for an empty WhereIn", query)
+	}
+	if len(args) != 0 {
+		t.Errorf("Build() args = %v, want none", args)
+	}
+}
+
+func TestQueryWhereInRendersPlaceholders(t *testing.T) {
+	q := queryFor(metaTestRecord{}, MySQLDialect{})
+	q.WhereIn("id", int64(1), int64(2), int64(3))
+
+	query, args := q.Build()
+	if !strings.Contains(query, "`id` IN (?, ?, ?)") {
+		t.Errorf("Build() = %q, want an IN clause with 3 placeholders", query)
+	}
+	if len(args) != 3 {
+		t.Errorf("Build() args = %v, want 3 values", args)
+	}
+}
+
+func TestQueryGroupBuildsOrPredicate(t *testing.T) {
+	q := queryFor(metaTestRecord{}, MySQLDialect{})
+	q.Where("id", "=", int64(1)).Group(func(g *Query) {
+		g.Where("name", "=", "a").OrWhere("name", "=", "b")
+	})
+
+	query, args := q.Build()
+	if want := "SELECT * FROM `meta_test_records` WHERE `id` = ? AND (`name` = ? OR `name` = ?)"; query != want {
+		t.Errorf("Build() = %q, want %q", query, want)
+	}
+	if len(args) != 3 {
+		t.Errorf("Build() args = %v, want 3 values", args)
+	}
+}
+
+func TestQueryOrderByAndGroupByDropUnwhitelistedColumns(t *testing.T) {
+	q := queryFor(metaTestRecord{}, MySQLDialect{})
+	q.OrderBy("name", "desc").OrderBy("not_a_real_column", "asc")
+	q.GroupBy("name", "not_a_real_column")
+
+	query, _ := q.Build()
+	if want := "SELECT * FROM `meta_test_records` GROUP BY `name` ORDER BY `name` DESC"; query != want {
+		t.Errorf("Build() = %q, want %q", query, want)
+	}
+}
+
+func TestCountQGroupedIncludesHaving(t *testing.T) {
+	db := &fakeDB{}
+	repo := NewRepository(db, metaTestRecord{}, MySQLDialect{})
+
+	q := repo.Query().GroupBy("name").Having("COUNT(*) > ?", int64(5))
+	if _, err := repo.CountQ(context.Background(), q); err != nil {
+		t.Fatalf("CountQ() error = %v", err)
+	}
+
+	if len(db.selects) != 1 {
+		t.Fatalf("CountQ() issued %d Select calls, want 1", len(db.selects))
+	}
+
+	got := db.selects[0]
+	if !strings.Contains(got.query, "GROUP BY `name` HAVING COUNT(*) > ?") {
+		t.Errorf("CountQ() query = %q, want the grouped subquery to carry the HAVING clause", got.query)
+	}
+	if len(got.args) != 1 || got.args[0] != int64(5) {
+		t.Errorf("CountQ() args = %v, want [5] (the HAVING argument)", got.args)
+	}
+}
+
+func TestFindRewritesPlaceholdersForRepositoryDialect(t *testing.T) {
+	db := &fakeDB{}
+	repo := NewRepository(db, metaTestRecord{}, PostgresDialect{})
+
+	q := queryFor(metaTestRecord{}, PostgresDialect{}).Where("name", "=", "ada")
+	var dest []metaTestRecord
+	if err := repo.Find(context.Background(), &dest, q); err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+
+	if len(db.selects) != 1 {
+		t.Fatalf("Find() issued %d Select calls, want 1", len(db.selects))
+	}
+	if want := `SELECT * FROM "meta_test_records" WHERE "name" = $1`; db.selects[0].query != want {
+		t.Errorf("Find() query = %q, want %q", db.selects[0].query, want)
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
-	"reflect"
 	"strconv"
 	"strings"
 )
@@ -14,13 +13,29 @@ import (
 // Repository holder for common queries.
 // Note: each entity service keeps its own base Repository instance.
 type Repository struct {
-	db  Database
-	rec Record // see `Count`, `List` and `DeleteByID` methods.
+	db      Database
+	rec     Record  // see `Count`, `List` and `DeleteByID` methods.
+	dialect Dialect // see `Count`, `List` and `DeleteByID` methods.
+
+	tx         Tx     // non-nil when this repository is bound to a transaction, see `WithTx`.
+	savepoints *int32 // shared SAVEPOINT name counter across a `WithTx` nesting chain.
 }
 
 // NewRepository returns a new (SQL) base service for common operations.
-func NewRepository(db Database, of Record) *Repository {
-	return &Repository{db: db, rec: of}
+// An optional `Dialect` can be given to target a database engine other than
+// MySQL (the default), e.g. `NewRepository(db, of, PostgresDialect{})`.
+func NewRepository(db Database, of Record, dialect ...Dialect) *Repository {
+	d := defaultDialect
+	if len(dialect) > 0 && dialect[0] != nil {
+		d = dialect[0]
+	}
+
+	return &Repository{db: db, rec: of, dialect: d}
+}
+
+// Dialect exposes the repository's active `Dialect`.
+func (r *Repository) Dialect() Dialect {
+	return r.dialect
 }
 
 // DB exposes the database instance.
@@ -38,8 +53,10 @@ func (r *Repository) RecordInfo() Record {
 var ErrNoRows = sql.ErrNoRows
 
 // Count returns the total records count in the table.
+// Soft-deleted records (see `SoftDeleted`) are excluded.
 func (r *Repository) Count(ctx context.Context) (total int64, err error) {
-	q := fmt.Sprintf("SELECT COUNT(DISTINCT %s) FROM %s", r.rec.PrimaryKey(), r.rec.TableName())
+	q := fmt.Sprintf("SELECT COUNT(DISTINCT %s) FROM %s", r.dialect.Quote(r.rec.PrimaryKey()), r.dialect.Quote(r.rec.TableName()))
+	q += excludeDeletedClause(r.rec, r.dialect, false)
 	if err = r.db.Select(ctx, &total, q); err == sql.ErrNoRows {
 		err = nil
 	}
@@ -47,12 +64,18 @@ func (r *Repository) Count(ctx context.Context) (total int64, err error) {
 }
 
 // GetByID binds a single record from the databases to the "dest".
+// Soft-deleted records (see `SoftDeleted`) are excluded.
 func (r *Repository) GetByID(ctx context.Context, dest interface{}, id int64) error {
-	q := fmt.Sprintf("SELECT * FROM %s WHERE %s = ? LIMIT 1", r.rec.TableName(), r.rec.PrimaryKey())
+	q := fmt.Sprintf("SELECT * FROM %s WHERE %s = ?", r.dialect.Quote(r.rec.TableName()), r.dialect.Quote(r.rec.PrimaryKey()))
+	q += excludeDeletedClause(r.rec, r.dialect, true)
+	q += " LIMIT 1"
+	q = rewritePlaceholders(q, r.dialect)
 	err := r.db.Get(ctx, dest, q, id)
 	return err
 }
 
+// GetByAttrs binds a single record matching "attrs" to "dest".
+// Soft-deleted records (see `SoftDeleted`) are excluded.
 func (r *Repository) GetByAttrs(ctx context.Context, dest interface{}, attrs map[string]interface{}) error {
 	if len(attrs) == 0 {
 		return nil
@@ -64,7 +87,7 @@ func (r *Repository) GetByAttrs(ctx context.Context, dest interface{}, attrs map
 	)
 
 	for k, v := range attrs {
-		keyLines = append(keyLines, fmt.Sprintf("%s = ?", k))
+		keyLines = append(keyLines, fmt.Sprintf("%s = ?", r.dialect.Quote(k)))
 		values = append(values, v)
 	}
 
@@ -72,8 +95,11 @@ func (r *Repository) GetByAttrs(ctx context.Context, dest interface{}, attrs map
 		return nil
 	}
 
-	q := fmt.Sprintf("SELECT * FROM %s WHERE %s;",
-		r.rec.TableName(), strings.Join(keyLines, ", "))
+	q := fmt.Sprintf("SELECT * FROM %s WHERE %s",
+		r.dialect.Quote(r.rec.TableName()), strings.Join(keyLines, ", "))
+	q += excludeDeletedClause(r.rec, r.dialect, true)
+	q += ";"
+	q = rewritePlaceholders(q, r.dialect)
 
 	err := r.db.Get(ctx, dest, q, values...)
 	if err != nil {
@@ -83,32 +109,67 @@ func (r *Repository) GetByAttrs(ctx context.Context, dest interface{}, attrs map
 	return nil
 }
 
+// GetAll binds every record of the table to "dest".
+// Soft-deleted records (see `SoftDeleted`) are excluded.
 func (r *Repository) GetAll(ctx context.Context, dest interface{}) error {
-	q := fmt.Sprintf("SELECT * FROM %s", r.rec.TableName())
+	q := fmt.Sprintf("SELECT * FROM %s", r.dialect.Quote(r.rec.TableName()))
+	q += excludeDeletedClause(r.rec, r.dialect, false)
 	err := r.db.Get(ctx, dest, q)
 	return err
 }
 
 // DeleteByID removes a single record of "dest" from the database.
+// If the record implements `SoftDeleted` this performs a logical delete
+// (an `UPDATE` that sets its deleted-at column) instead of a `DELETE`,
+// use `HardDelete` to always remove the row.
 func (r *Repository) DeleteByID(ctx context.Context, id int64) (int, error) {
-	q := fmt.Sprintf("DELETE FROM %s WHERE %s = ? LIMIT 1", r.rec.TableName(), r.rec.PrimaryKey())
-	res, err := r.db.Exec(ctx, q, id)
-	if err != nil {
-		return 0, err
+	if col, ok := softDeleteColumn(r.rec); ok {
+		q := fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s = ?",
+			r.dialect.Quote(r.rec.TableName()), r.dialect.Quote(col), r.dialect.CurrentTimestamp(), r.dialect.Quote(r.rec.PrimaryKey()))
+		q = rewritePlaceholders(q, r.dialect)
+
+		res, err := r.db.Exec(ctx, q, id)
+		if err != nil {
+			return 0, err
+		}
+
+		return GetAffectedRows(res), nil
 	}
 
-	return GetAffectedRows(res), nil
+	return r.HardDelete(ctx, id)
 }
 
 // ListOptions holds the options to be passed on the `Service.List` method.
 type ListOptions struct {
 	Table         string // the table name.
-	Offset        uint64 // inclusive.
+	Offset        uint64 // inclusive, ignored once `Cursor` is set.
 	Limit         uint64
 	OrderByColumn string
 	Order         string // "ASC" or "DESC" (could be a bool type instead).
 	WhereColumn   string
 	WhereValue    interface{}
+	Dialect       Dialect // defaults to `defaultDialect` (MySQL) when nil, see `Repository.List`.
+
+	// Cursor, when set, switches `BuildQuery` from `OFFSET` pagination to a
+	// keyset predicate seeded by this opaque, `EncodeCursor`-produced token
+	// instead. Leave it empty to keep the existing `Offset` behavior.
+	Cursor string
+	// CursorDirection is "next" (`CursorNext`, the default) or "prev"
+	// (`CursorPrev`), selecting which side of `Cursor` to page towards.
+	CursorDirection string
+	// PrimaryKeyColumn is the tiebreaker column paired with `OrderByColumn`
+	// in the keyset predicate, since `OrderByColumn` alone isn't guaranteed
+	// unique. Populated automatically by `Repository.List` from the
+	// record's `PrimaryKey()`.
+	PrimaryKeyColumn string
+
+	// DeletedAtColumn, when set, excludes rows where it is non-NULL. It's
+	// populated automatically by `Repository.List` from the record's
+	// `SoftDeleted` implementation, if any.
+	DeletedAtColumn string
+	// IncludeDeleted disables the automatic `DeletedAtColumn` filtering,
+	// an escape hatch to list soft-deleted rows too.
+	IncludeDeleted bool
 }
 
 // Where accepts a column name and column value to set
@@ -121,29 +182,94 @@ func (opt ListOptions) Where(colName string, colValue interface{}) ListOptions {
 	return opt
 }
 
-// BuildQuery returns the query and the arguments that
-// should be form a SELECT command.
-func (opt ListOptions) BuildQuery() (q string, args []interface{}) {
-	q = fmt.Sprintf("SELECT * FROM %s", opt.Table)
+// BuildQuery returns the query and the arguments that should form a SELECT
+// command. If `Cursor` is set it emits a compound keyset predicate
+// `(order_col, pk) > (?, ?)` in place of the `OFFSET` clause, using `<`
+// instead whenever the rows are fetched in descending order (`CursorPrev`
+// on an ascending `Order`, or `CursorNext` on a descending one); otherwise
+// it falls back to the existing `Offset`
+// behavior. For `CursorPrev` the row is also fetched in the opposite sort
+// direction (so `LIMIT` picks the rows immediately before the cursor
+// instead of the first page of the whole table), `buildPageInfo` reverses
+// it back to the requested order afterwards. Returns `ErrInvalidCursor` if
+// `Cursor` doesn't decode for `OrderByColumn`.
+func (opt ListOptions) BuildQuery() (q string, args []interface{}, err error) {
+	d := opt.Dialect
+	if d == nil {
+		d = defaultDialect
+	}
+
+	q = fmt.Sprintf("SELECT * FROM %s", d.Quote(opt.Table))
 
-	if opt.WhereColumn != "" && opt.WhereValue != nil {
-		q += fmt.Sprintf(" WHERE %s = ?", opt.WhereColumn)
+	hasWhere := opt.WhereColumn != "" && opt.WhereValue != nil
+	if hasWhere {
+		q += fmt.Sprintf(" WHERE %s = ?", d.Quote(opt.WhereColumn))
 		args = append(args, opt.WhereValue)
 	}
 
-	if opt.OrderByColumn != "" {
-		q += fmt.Sprintf(" ORDER BY %s %s", opt.OrderByColumn, ParseOrder(opt.Order))
+	if opt.DeletedAtColumn != "" && !opt.IncludeDeleted {
+		if hasWhere {
+			q += fmt.Sprintf(" AND %s IS NULL", d.Quote(opt.DeletedAtColumn))
+		} else {
+			q += fmt.Sprintf(" WHERE %s IS NULL", d.Quote(opt.DeletedAtColumn))
+			hasWhere = true
+		}
 	}
 
-	if opt.Limit > 0 {
-		q += fmt.Sprintf(" LIMIT %d", opt.Limit) // offset below.
+	// fetchDesc is the direction the query must physically sort in for
+	// `LIMIT` to land on the right rows: the requested order, flipped when
+	// paging backwards (`CursorPrev`) so the rows immediately before the
+	// cursor come first instead of the first page of the whole table.
+	fetchDesc := ParseOrder(opt.Order) == descending
+
+	if opt.Cursor != "" {
+		if opt.CursorDirection == CursorPrev {
+			fetchDesc = !fetchDesc
+		}
+
+		orderVal, pkVal, decErr := DecodeCursor(opt.Cursor, opt.OrderByColumn)
+		if decErr != nil {
+			return "", nil, decErr
+		}
+
+		op := ">"
+		if fetchDesc {
+			op = "<"
+		}
+
+		pred := fmt.Sprintf("(%s, %s) %s (?, ?)", d.Quote(opt.OrderByColumn), d.Quote(opt.PrimaryKeyColumn), op)
+		if hasWhere {
+			q += " AND " + pred
+		} else {
+			q += " WHERE " + pred
+		}
+		args = append(args, orderVal, pkVal)
 	}
 
-	if opt.Offset > 0 {
-		q += fmt.Sprintf(" OFFSET %d", opt.Offset)
+	if opt.OrderByColumn != "" {
+		dir := ParseOrder(opt.Order)
+		if opt.Cursor != "" {
+			dir = ascending
+			if fetchDesc {
+				dir = descending
+			}
+		}
+
+		q += fmt.Sprintf(" ORDER BY %s %s", d.Quote(opt.OrderByColumn), dir)
+		if opt.Cursor != "" && opt.PrimaryKeyColumn != "" {
+			q += fmt.Sprintf(", %s %s", d.Quote(opt.PrimaryKeyColumn), dir)
+		}
 	}
 
-	return
+	if opt.Cursor != "" {
+		q += d.LimitOffset(opt.Limit, 0)
+	} else {
+		q += d.LimitOffset(opt.Limit, opt.Offset)
+	}
+
+	q = rewritePlaceholders(q, d)
+
+	return q, args, nil
 }
 
 // const defaultLimit = 30 // default limit if not set.
@@ -154,14 +280,31 @@ func ParseListOptions(q url.Values) ListOptions {
 	limit, _ := strconv.ParseUint(q.Get("limit"), 10, 64)
 	order := q.Get("order") // empty, asc(...) or desc(...).
 	orderBy := q.Get("by")  // e.g. price
+	cursor := q.Get("cursor")
+	direction := q.Get("direction") // "next" or "prev".
+
+	return ListOptions{
+		Offset: offset, Limit: limit, Order: order, OrderByColumn: orderBy,
+		Cursor: cursor, CursorDirection: direction,
+	}
+}
 
-	return ListOptions{Offset: offset, Limit: limit, Order: order, OrderByColumn: orderBy}
+// PageInfo describes the cursors around a `Repository.List` page, returned
+// alongside the bound records. `NextCursor`/`PrevCursor` are empty when the
+// page is itself empty.
+type PageInfo struct {
+	NextCursor string
+	PrevCursor string
+	HasMore    bool // whether another row exists past the fetched page, in the requested direction.
 }
 
 // List binds one or more records from the database to the "dest".
 // If the record supports ordering then it will sort by the `Sorted.OrderBy` column name(s).
 // Use the "order" input parameter to set a descending order ("DESC").
-func (r *Repository) List(ctx context.Context, dest interface{}, opts ListOptions) error {
+// When `opts.Cursor` is set, it fetches one extra row into "dest" to
+// populate the returned `PageInfo` and then trims "dest" back down to
+// `opts.Limit`, see `ListOptions.BuildQuery`.
+func (r *Repository) List(ctx context.Context, dest interface{}, opts ListOptions) (PageInfo, error) {
 	// Set table and order by column from record info for `List` by options
 	// so it can be more flexible to perform read-only calls of other table's too.
 	if opts.Table == "" {
@@ -173,9 +316,39 @@ func (r *Repository) List(ctx context.Context, dest interface{}, opts ListOption
 			opts.OrderByColumn = b.SortBy()
 		}
 	}
+	if opts.Dialect == nil {
+		opts.Dialect = r.dialect
+	}
+	if opts.DeletedAtColumn == "" {
+		if col, ok := softDeleteColumn(r.rec); ok {
+			opts.DeletedAtColumn = col
+		}
+	}
+	if opts.PrimaryKeyColumn == "" {
+		opts.PrimaryKeyColumn = r.rec.PrimaryKey()
+	}
 
-	q, args := opts.BuildQuery()
-	return r.db.Select(ctx, dest, q, args...)
+	if opts.Cursor == "" {
+		q, args, err := opts.BuildQuery()
+		if err != nil {
+			return PageInfo{}, err
+		}
+		return PageInfo{}, r.db.Select(ctx, dest, q, args...)
+	}
+
+	limit := opts.Limit
+	opts.Limit = limit + 1
+
+	q, args, err := opts.BuildQuery()
+	if err != nil {
+		return PageInfo{}, err
+	}
+
+	if err := r.db.Select(ctx, dest, q, args...); err != nil {
+		return PageInfo{}, err
+	}
+
+	return buildPageInfo(r.rec, dest, limit, opts), nil
 }
 
 // ErrUnprocessable indicates error caused by invalid entity (entity's key-values).
@@ -186,41 +359,34 @@ func (r *Repository) List(ctx context.Context, dest interface{}, opts ListOption
 // and `PartialUpdate`.
 var ErrUnprocessable = errors.New("invalid entity")
 
-// PartialUpdate accepts a columns schema and a key-value map to
-// update the record based on the given "id".
+// PartialUpdate accepts a key-value map to update the record based on the
+// given "id". The allowed columns, their kinds and their pk/readonly
+// modifiers come from the `recordMeta` registered for the repository's
+// record via `RegisterRecord`, callers no longer pass a parallel schema.
 // Note: Trivial string, int and boolean type validations are performed here.
-func (r *Repository) PartialUpdate(ctx context.Context, id int64, schema map[string]reflect.Kind, attrs map[string]interface{}) (int, error) {
-	if len(schema) == 0 || len(attrs) == 0 {
+func (r *Repository) PartialUpdate(ctx context.Context, id int64, attrs map[string]interface{}) (int, error) {
+	if len(attrs) == 0 {
 		return 0, nil
 	}
 
+	meta := getRecordMeta(r.rec)
+
 	var (
 		keyLines []string
 		values   []interface{}
 	)
 
-	for key, kind := range schema {
-		v, ok := attrs[key]
-		if !ok {
+	for key, v := range attrs {
+		col, ok := meta.byName[key]
+		if !ok || col.PK || col.ReadOnly {
 			continue
 		}
 
-		switch v.(type) {
-		case string:
-			if kind != reflect.String {
-				return 0, ErrUnprocessable
-			}
-		case int:
-			if kind != reflect.Int {
-				return 0, ErrUnprocessable
-			}
-		case bool:
-			if kind != reflect.Bool {
-				return 0, ErrUnprocessable
-			}
+		if !kindMatches(col.Kind, v) {
+			return 0, ErrUnprocessable
 		}
 
-		keyLines = append(keyLines, fmt.Sprintf("%s = ?", key))
+		keyLines = append(keyLines, fmt.Sprintf("%s = ?", r.dialect.Quote(key)))
 		values = append(values, v)
 	}
 
@@ -229,7 +395,8 @@ func (r *Repository) PartialUpdate(ctx context.Context, id int64, schema map[str
 	}
 
 	q := fmt.Sprintf("UPDATE %s SET %s WHERE %s = ?;",
-		r.rec.TableName(), strings.Join(keyLines, ", "), r.rec.PrimaryKey())
+		r.dialect.Quote(r.rec.TableName()), strings.Join(keyLines, ", "), r.dialect.Quote(r.rec.PrimaryKey()))
+	q = rewritePlaceholders(q, r.dialect)
 
 	res, err := r.DB().Exec(ctx, q, append(values, id)...)
 	if err != nil {
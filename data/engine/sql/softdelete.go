@@ -0,0 +1,83 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+)
+
+// SoftDeleted is implemented by `Record` types that want logical deletion
+// instead of a hard `DELETE`. When a record implements it, `Repository`
+// automatically excludes soft-deleted rows from `Count`, `GetByID`,
+// `GetByAttrs`, `GetAll` and `List`, and routes `DeleteByID` through an
+// `UPDATE ... SET <col> = <now>` (the `Dialect`'s `CurrentTimestamp`)
+// instead of removing the row.
+type SoftDeleted interface {
+	// DeletedAtColumn returns the name of the nullable timestamp column that
+	// marks a row as deleted, e.g. "deleted_at".
+	DeletedAtColumn() string
+}
+
+// softDeleteColumn returns the soft-delete column of "rec" and whether it
+// supports `SoftDeleted` at all.
+func softDeleteColumn(rec Record) (string, bool) {
+	sd, ok := rec.(SoftDeleted)
+	if !ok {
+		return "", false
+	}
+
+	return sd.DeletedAtColumn(), true
+}
+
+// excludeDeletedClause returns " AND <col> IS NULL" (or " WHERE <col> IS
+// NULL" when "hasWhere" is false) if "rec" is soft-deletable, "" otherwise.
+func excludeDeletedClause(rec Record, dialect Dialect, hasWhere bool) string {
+	col, ok := softDeleteColumn(rec)
+	if !ok {
+		return ""
+	}
+
+	if hasWhere {
+		return fmt.Sprintf(" AND %s IS NULL", dialect.Quote(col))
+	}
+
+	return fmt.Sprintf(" WHERE %s IS NULL", dialect.Quote(col))
+}
+
+// Restore clears the soft-delete marker of the record identified by "id",
+// making it visible again. It's a no-op returning `ErrUnprocessable` if the
+// repository's record doesn't implement `SoftDeleted`.
+func (r *Repository) Restore(ctx context.Context, id int64) (int, error) {
+	col, ok := softDeleteColumn(r.rec)
+	if !ok {
+		return 0, ErrUnprocessable
+	}
+
+	q := fmt.Sprintf("UPDATE %s SET %s = NULL WHERE %s = ?",
+		r.dialect.Quote(r.rec.TableName()), r.dialect.Quote(col), r.dialect.Quote(r.rec.PrimaryKey()))
+	q = rewritePlaceholders(q, r.dialect)
+
+	res, err := r.db.Exec(ctx, q, id)
+	if err != nil {
+		return 0, err
+	}
+
+	return GetAffectedRows(res), nil
+}
+
+// HardDelete permanently removes the record identified by "id", bypassing
+// `SoftDeleted` entirely. Use `DeleteByID` for the usual (soft, when
+// supported) deletion path.
+func (r *Repository) HardDelete(ctx context.Context, id int64) (int, error) {
+	// No "LIMIT 1": the "WHERE pk = ?" already bounds this to at most one
+	// row, and DELETE...LIMIT is MySQL/SQLite-only syntax, a hard error on
+	// Postgres.
+	q := fmt.Sprintf("DELETE FROM %s WHERE %s = ?", r.dialect.Quote(r.rec.TableName()), r.dialect.Quote(r.rec.PrimaryKey()))
+	q = rewritePlaceholders(q, r.dialect)
+
+	res, err := r.db.Exec(ctx, q, id)
+	if err != nil {
+		return 0, err
+	}
+
+	return GetAffectedRows(res), nil
+}
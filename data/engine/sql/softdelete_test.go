@@ -0,0 +1,119 @@
+package sql
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type softDeleteTestRecord struct {
+	ID int64 `db:"id,pk"`
+}
+
+func (softDeleteTestRecord) TableName() string       { return "soft_delete_test_records" }
+func (softDeleteTestRecord) PrimaryKey() string      { return "id" }
+func (softDeleteTestRecord) DeletedAtColumn() string { return "deleted_at" }
+
+type hardDeleteTestRecord struct {
+	ID int64 `db:"id,pk"`
+}
+
+func (hardDeleteTestRecord) TableName() string  { return "hard_delete_test_records" }
+func (hardDeleteTestRecord) PrimaryKey() string { return "id" }
+
+func TestDeleteByIDUsesDialectCurrentTimestamp(t *testing.T) {
+	cases := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{MySQLDialect{}, "NOW()"},
+		{PostgresDialect{}, "NOW()"},
+		{SQLiteDialect{}, "CURRENT_TIMESTAMP"},
+	}
+
+	for _, c := range cases {
+		t.Run(reflect.TypeOf(c.dialect).Name(), func(t *testing.T) {
+			db := &fakeDB{}
+			repo := NewRepository(db, softDeleteTestRecord{}, c.dialect)
+
+			if _, err := repo.DeleteByID(context.Background(), 1); err != nil {
+				t.Fatalf("DeleteByID() error = %v", err)
+			}
+
+			if len(db.execs) != 1 {
+				t.Fatalf("DeleteByID() issued %d Exec calls, want 1", len(db.execs))
+			}
+			if !strings.Contains(db.execs[0].query, "= "+c.want+" WHERE") {
+				t.Errorf("DeleteByID() query = %q, want the soft-delete UPDATE to stamp %q", db.execs[0].query, c.want)
+			}
+		})
+	}
+}
+
+func TestDeleteByIDFallsBackToHardDeleteWithoutSoftDelete(t *testing.T) {
+	db := &fakeDB{}
+	repo := NewRepository(db, hardDeleteTestRecord{}, MySQLDialect{})
+
+	if _, err := repo.DeleteByID(context.Background(), 1); err != nil {
+		t.Fatalf("DeleteByID() error = %v", err)
+	}
+
+	if len(db.execs) != 1 {
+		t.Fatalf("DeleteByID() issued %d Exec calls, want 1", len(db.execs))
+	}
+	if !strings.HasPrefix(db.execs[0].query, "DELETE FROM") {
+		t.Errorf("DeleteByID() query = %q, want a DELETE for a non-soft-deletable record", db.execs[0].query)
+	}
+	if strings.Contains(db.execs[0].query, "LIMIT") {
+		t.Errorf("DeleteByID() query = %q, want no LIMIT (DELETE...LIMIT is a syntax error on Postgres)", db.execs[0].query)
+	}
+}
+
+func TestRestoreClearsDeletedAtColumn(t *testing.T) {
+	db := &fakeDB{}
+	repo := NewRepository(db, softDeleteTestRecord{}, PostgresDialect{})
+
+	if _, err := repo.Restore(context.Background(), 1); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	if len(db.execs) != 1 {
+		t.Fatalf("Restore() issued %d Exec calls, want 1", len(db.execs))
+	}
+	if want := `UPDATE "soft_delete_test_records" SET "deleted_at" = NULL WHERE "id" = $1`; db.execs[0].query != want {
+		t.Errorf("Restore() query = %q, want %q", db.execs[0].query, want)
+	}
+}
+
+func TestRestoreRejectsNonSoftDeletableRecord(t *testing.T) {
+	db := &fakeDB{}
+	repo := NewRepository(db, hardDeleteTestRecord{}, MySQLDialect{})
+
+	if _, err := repo.Restore(context.Background(), 1); err != ErrUnprocessable {
+		t.Errorf("Restore() error = %v, want ErrUnprocessable", err)
+	}
+	if len(db.execs) != 0 {
+		t.Errorf("Restore() issued %d Exec calls, want 0 for a non-soft-deletable record", len(db.execs))
+	}
+}
+
+func TestExcludeDeletedClauseAppendsToExistingWhere(t *testing.T) {
+	got := excludeDeletedClause(softDeleteTestRecord{}, MySQLDialect{}, true)
+	if want := " AND `deleted_at` IS NULL"; got != want {
+		t.Errorf("excludeDeletedClause() = %q, want %q", got, want)
+	}
+}
+
+func TestExcludeDeletedClauseStartsWhereWhenAbsent(t *testing.T) {
+	got := excludeDeletedClause(softDeleteTestRecord{}, MySQLDialect{}, false)
+	if want := " WHERE `deleted_at` IS NULL"; got != want {
+		t.Errorf("excludeDeletedClause() = %q, want %q", got, want)
+	}
+}
+
+func TestExcludeDeletedClauseEmptyForNonSoftDeletable(t *testing.T) {
+	if got := excludeDeletedClause(hardDeleteTestRecord{}, MySQLDialect{}, true); got != "" {
+		t.Errorf("excludeDeletedClause() = %q, want empty for a non-soft-deletable record", got)
+	}
+}
@@ -0,0 +1,95 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// Tx is a transaction-scoped `Database`, returned by a `Transactional`
+// backend's `BeginTx`.
+type Tx interface {
+	Database
+	Commit() error
+	Rollback() error
+}
+
+// Transactional is implemented by `Database` backends that can open a
+// transaction. `Repository.WithTx` requires it, a `Database` that doesn't
+// implement it (e.g. a read-only replica wrapper) simply can't be used
+// transactionally.
+type Transactional interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error)
+}
+
+// TxOptions configures `Repository.WithTx`.
+type TxOptions struct {
+	Isolation sql.IsolationLevel
+	ReadOnly  bool
+}
+
+// ErrTxNotSupported is returned by `Repository.WithTx` when the repository's
+// `Database` doesn't implement `Transactional`.
+var ErrTxNotSupported = errors.New("sql: database does not support transactions")
+
+// WithTx runs "fn" with a `Repository` bound to a transaction: it opens one
+// on the underlying `Database` (or, if "r" is already transaction-bound,
+// opens a SAVEPOINT instead so nested calls compose), commits on a nil
+// return and rolls back otherwise. This is what lets callers chain
+// multi-record updates atomically, e.g. a `PartialUpdate` plus an
+// audit-log `Insert`.
+func (r *Repository) WithTx(ctx context.Context, fn func(*Repository) error, opts ...TxOptions) error {
+	var o TxOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	if r.tx != nil {
+		return r.withSavepoint(ctx, fn)
+	}
+
+	txDB, ok := r.db.(Transactional)
+	if !ok {
+		return ErrTxNotSupported
+	}
+
+	tx, err := txDB.BeginTx(ctx, &sql.TxOptions{Isolation: o.Isolation, ReadOnly: o.ReadOnly})
+	if err != nil {
+		return err
+	}
+
+	inner := &Repository{db: tx, rec: r.rec, dialect: r.dialect, tx: tx, savepoints: new(int32)}
+
+	if err := fn(inner); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// withSavepoint implements the nested case of `WithTx`: "r" is already
+// bound to a transaction, so a SAVEPOINT stands in for BEGIN/COMMIT.
+func (r *Repository) withSavepoint(ctx context.Context, fn func(*Repository) error) error {
+	name := fmt.Sprintf("sp_%d", atomic.AddInt32(r.savepoints, 1))
+
+	if _, err := r.tx.Exec(ctx, "SAVEPOINT "+name); err != nil {
+		return err
+	}
+
+	inner := &Repository{db: r.tx, rec: r.rec, dialect: r.dialect, tx: r.tx, savepoints: r.savepoints}
+
+	if err := fn(inner); err != nil {
+		if _, rbErr := r.tx.Exec(ctx, "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+			return fmt.Errorf("%w (savepoint rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	_, err := r.tx.Exec(ctx, "RELEASE SAVEPOINT "+name)
+	return err
+}
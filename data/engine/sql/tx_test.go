@@ -0,0 +1,171 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+// fakeTx wraps a fakeDB so a WithTx/withSavepoint nesting chain can be
+// asserted on from one place: every Exec a transaction or its nested
+// SAVEPOINTs issue lands in the same fakeDB.execs slice.
+type fakeTx struct {
+	*fakeDB
+	commitErr   error
+	rollbackErr error
+	committed   bool
+	rolledBack  bool
+}
+
+func (tx *fakeTx) Commit() error {
+	tx.committed = true
+	return tx.commitErr
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.rolledBack = true
+	return tx.rollbackErr
+}
+
+// txFakeDB is a fakeDB that also implements Transactional, spawning a
+// fakeTx sharing its own recording state.
+type txFakeDB struct {
+	fakeDB
+	tx *fakeTx
+
+	beginErr    error
+	commitErr   error
+	rollbackErr error
+}
+
+func (d *txFakeDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	if d.beginErr != nil {
+		return nil, d.beginErr
+	}
+	d.tx = &fakeTx{fakeDB: &d.fakeDB, commitErr: d.commitErr, rollbackErr: d.rollbackErr}
+	return d.tx, nil
+}
+
+func TestWithTxCommitsOnSuccess(t *testing.T) {
+	db := &txFakeDB{}
+	repo := NewRepository(db, metaTestRecord{}, MySQLDialect{})
+
+	err := repo.WithTx(context.Background(), func(inner *Repository) error {
+		_, execErr := inner.DB().Exec(context.Background(), "INSERT INTO x VALUES (1)")
+		return execErr
+	})
+	if err != nil {
+		t.Fatalf("WithTx() error = %v", err)
+	}
+
+	if !db.tx.committed {
+		t.Errorf("WithTx() did not commit on success")
+	}
+	if db.tx.rolledBack {
+		t.Errorf("WithTx() rolled back on success")
+	}
+}
+
+func TestWithTxRollsBackOnError(t *testing.T) {
+	db := &txFakeDB{}
+	repo := NewRepository(db, metaTestRecord{}, MySQLDialect{})
+
+	wantErr := context.Canceled
+	err := repo.WithTx(context.Background(), func(inner *Repository) error {
+		return wantErr
+	})
+
+	if err != wantErr {
+		t.Errorf("WithTx() error = %v, want %v unwrapped", err, wantErr)
+	}
+	if !db.tx.rolledBack {
+		t.Errorf("WithTx() did not roll back on fn error")
+	}
+	if db.tx.committed {
+		t.Errorf("WithTx() committed despite fn error")
+	}
+}
+
+func TestWithTxWrapsErrorWhenRollbackFails(t *testing.T) {
+	rollbackErr := context.DeadlineExceeded
+	db := &txFakeDB{rollbackErr: rollbackErr}
+	repo := NewRepository(db, metaTestRecord{}, MySQLDialect{})
+
+	fnErr := context.Canceled
+	err := repo.WithTx(context.Background(), func(inner *Repository) error {
+		return fnErr
+	})
+
+	if err == nil || err == fnErr {
+		t.Fatalf("WithTx() error = %v, want fnErr wrapped with the rollback failure", err)
+	}
+}
+
+func TestWithTxReturnsErrTxNotSupported(t *testing.T) {
+	db := &fakeDB{}
+	repo := NewRepository(db, metaTestRecord{}, MySQLDialect{})
+
+	err := repo.WithTx(context.Background(), func(inner *Repository) error { return nil })
+	if err != ErrTxNotSupported {
+		t.Errorf("WithTx() error = %v, want ErrTxNotSupported", err)
+	}
+}
+
+func TestWithTxNestingUsesSavepoints(t *testing.T) {
+	db := &txFakeDB{}
+	repo := NewRepository(db, metaTestRecord{}, MySQLDialect{})
+
+	err := repo.WithTx(context.Background(), func(outer *Repository) error {
+		if err := outer.WithTx(context.Background(), func(inner *Repository) error {
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		return outer.WithTx(context.Background(), func(inner *Repository) error {
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("WithTx() error = %v", err)
+	}
+
+	want := []string{"SAVEPOINT sp_1", "RELEASE SAVEPOINT sp_1", "SAVEPOINT sp_2", "RELEASE SAVEPOINT sp_2"}
+	if len(db.fakeDB.execs) != len(want) {
+		t.Fatalf("WithTx() nested execs = %v, want %v", db.fakeDB.execs, want)
+	}
+	for i, w := range want {
+		if db.fakeDB.execs[i].query != w {
+			t.Errorf("WithTx() execs[%d] = %q, want %q", i, db.fakeDB.execs[i].query, w)
+		}
+	}
+}
+
+func TestWithTxNestingRollsBackToSavepointOnError(t *testing.T) {
+	db := &txFakeDB{}
+	repo := NewRepository(db, metaTestRecord{}, MySQLDialect{})
+
+	innerErr := context.Canceled
+	err := repo.WithTx(context.Background(), func(outer *Repository) error {
+		return outer.WithTx(context.Background(), func(inner *Repository) error {
+			return innerErr
+		})
+	})
+
+	if err != innerErr {
+		t.Errorf("WithTx() error = %v, want %v unwrapped", err, innerErr)
+	}
+
+	var sawRollbackTo bool
+	for _, c := range db.fakeDB.execs {
+		if c.query == "ROLLBACK TO SAVEPOINT sp_1" {
+			sawRollbackTo = true
+		}
+	}
+	if !sawRollbackTo {
+		t.Errorf("WithTx() execs = %v, want a \"ROLLBACK TO SAVEPOINT sp_1\"", db.fakeDB.execs)
+	}
+	if db.tx.committed {
+		t.Errorf("WithTx() committed the outer transaction despite the nested error")
+	}
+}